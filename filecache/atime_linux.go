@@ -0,0 +1,18 @@
+//go:build linux
+
+package filecache
+
+import (
+    "os"
+    "syscall"
+    "time"
+)
+
+// accessTime returns the last-accessed time for info, falling back to
+// ModTime if the platform-specific stat data isn't available.
+func accessTime(info os.FileInfo) time.Time {
+    if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+        return time.Unix(stat.Atim.Sec, stat.Atim.Nsec)
+    }
+    return info.ModTime()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package filecache
+
+import (
+    "os"
+    "time"
+)
+
+// accessTime falls back to ModTime on platforms where we don't special-case
+// the stat_t layout.
+func accessTime(info os.FileInfo) time.Time {
+    return info.ModTime()
+}
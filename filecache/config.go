@@ -0,0 +1,70 @@
+package filecache
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "time"
+)
+
+// EnvCacheDir is the environment variable that overrides the resolved value
+// of the ":cacheDir" placeholder, taking precedence over whatever the
+// config file sets as the default cache directory.
+const EnvCacheDir = "NEWSPAPERS_CACHEDIR"
+
+// CacheConfig is the YAML shape of a single entry under Config.Caches, e.g.:
+//
+//	caches:
+//	  pages:
+//	    dir: ":cacheDir/pages"
+//	    maxAge: 24h
+//	    maxSize: 536870912
+type CacheConfig struct {
+    Dir     string        `yaml:"dir"`
+    MaxAge  time.Duration `yaml:"maxAge"`
+    MaxSize int64         `yaml:"maxSize"`
+}
+
+// Manager owns the set of named caches configured in config.yaml and
+// resolves the ":cacheDir"/":tempDir" placeholders used in their Dir
+// settings.
+type Manager struct {
+    caches map[string]*Cache
+}
+
+// NewManager builds a Manager from the named cache configs, resolving
+// placeholders in each Dir against defaultCacheDir (normally "cache",
+// overridable via NEWSPAPERS_CACHEDIR) and os.TempDir.
+func NewManager(configs map[string]CacheConfig, defaultCacheDir string) (*Manager, error) {
+    cacheDir := defaultCacheDir
+    if env := os.Getenv(EnvCacheDir); env != "" {
+        cacheDir = env
+    }
+
+    m := &Manager{caches: make(map[string]*Cache, len(configs))}
+    for name, cfg := range configs {
+        c, err := New(name, Config{
+            Dir:     resolvePlaceholders(cfg.Dir, cacheDir),
+            MaxAge:  cfg.MaxAge,
+            MaxSize: cfg.MaxSize,
+        })
+        if err != nil {
+            return nil, fmt.Errorf("filecache: configuring cache %q: %v", name, err)
+        }
+        m.caches[name] = c
+    }
+    return m, nil
+}
+
+// Cache returns the named cache, or false if it isn't configured.
+func (m *Manager) Cache(name string) (*Cache, bool) {
+    c, ok := m.caches[name]
+    return c, ok
+}
+
+// resolvePlaceholders expands ":cacheDir" and ":tempDir" in dir.
+func resolvePlaceholders(dir, cacheDir string) string {
+    dir = strings.ReplaceAll(dir, ":cacheDir", cacheDir)
+    dir = strings.ReplaceAll(dir, ":tempDir", os.TempDir())
+    return dir
+}
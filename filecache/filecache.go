@@ -0,0 +1,233 @@
+// Package filecache implements a small, Hugo-style consolidated file cache.
+//
+// A Cache stores arbitrary byte streams on disk under a content-derived
+// filename, evicts entries once they are older than a configurable max age,
+// and keeps total on-disk size under a configurable max size using LRU
+// eviction based on file modification/access time. Concurrent requests for
+// the same key are coalesced with singleflight so that, for example, two
+// users hitting the home page at the same moment don't both trigger a
+// download of the same newspaper.
+package filecache
+
+import (
+    "crypto/sha256"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "golang.org/x/sync/singleflight"
+)
+
+// Forever and Disabled are the sentinel MaxAge values documented in
+// config.yaml: -1 means entries never expire, 0 means caching is disabled
+// and every lookup is treated as a miss.
+const (
+    Forever  = time.Duration(-1)
+    Disabled = time.Duration(0)
+)
+
+// Config describes how a single named cache should behave.
+type Config struct {
+    Dir     string // Directory entries are stored in. Must already be resolved (NewManager does this via resolvePlaceholders).
+    MaxAge  time.Duration
+    MaxSize int64 // Bytes. 0 means unbounded.
+}
+
+// Cache is a single named, disk-backed cache.
+type Cache struct {
+    name string
+    cfg  Config
+
+    mu    sync.Mutex // guards eviction bookkeeping
+    group singleflight.Group
+}
+
+// New creates a Cache backed by cfg.Dir, creating the directory if needed.
+func New(name string, cfg Config) (*Cache, error) {
+    if cfg.Dir == "" {
+        return nil, fmt.Errorf("filecache: cache %q has no directory configured", name)
+    }
+    if err := os.MkdirAll(cfg.Dir, os.ModePerm); err != nil {
+        return nil, fmt.Errorf("filecache: creating cache dir %q: %v", cfg.Dir, err)
+    }
+    return &Cache{name: name, cfg: cfg}, nil
+}
+
+// Dir returns the resolved directory this cache stores entries in, e.g. for
+// wiring up an http.FileServer alongside it.
+func (c *Cache) Dir() string {
+    return c.cfg.Dir
+}
+
+// Path returns the on-disk path an entry for key would be stored at,
+// whether or not it currently exists. Callers that need to serve a cache
+// entry directly (e.g. via http.ServeContent) use this instead of
+// reimplementing the naming scheme.
+func (c *Cache) Path(key string) string {
+    return c.filename(key)
+}
+
+// filename returns the on-disk path for key. Entries are named after a hash
+// of the key alone (not the date), so a still-fresh entry survives across
+// days; freshness is determined entirely by mtime in fresh/evict.
+func (c *Cache) filename(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return filepath.Join(c.cfg.Dir, fmt.Sprintf("%x", sum))
+}
+
+// fresh reports whether the entry described by info is still within MaxAge.
+func (c *Cache) fresh(info os.FileInfo) bool {
+    switch c.cfg.MaxAge {
+    case Disabled:
+        return false
+    case Forever:
+        return true
+    default:
+        return time.Since(info.ModTime()) < c.cfg.MaxAge
+    }
+}
+
+// GetOrCreate returns a reader for key, calling create to populate the cache
+// entry on a miss or expiry. create receives a writer for the new entry's
+// contents; if it returns an error the partially written entry is discarded.
+// Concurrent callers for the same key share a single in-flight create call.
+func (c *Cache) GetOrCreate(key string, create func(io.Writer) error) (io.ReadCloser, error) {
+    path := c.filename(key)
+
+    if info, err := os.Stat(path); err == nil && c.fresh(info) {
+        f, err := os.Open(path)
+        if err == nil {
+            now := time.Now()
+            _ = os.Chtimes(path, now, info.ModTime()) // bump atime for LRU without disturbing mtime-based expiry
+            return f, nil
+        }
+    }
+
+    v, err, _ := c.group.Do(key, func() (interface{}, error) {
+        if err := c.write(path, create); err != nil {
+            return nil, err
+        }
+        c.evict(path)
+        return nil, nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    _ = v
+
+    return os.Open(path)
+}
+
+// Peek returns a reader for key's entry if one exists and is still fresh,
+// without ever invoking a create func. It reports ok == false on a miss,
+// expiry, or disabled cache, exactly like a GetOrCreate miss would. Callers
+// use this to serve cached content without blocking on a download, falling
+// back to something else (e.g. a placeholder) when ok is false.
+func (c *Cache) Peek(key string) (rc io.ReadCloser, ok bool) {
+    path := c.filename(key)
+    info, err := os.Stat(path)
+    if err != nil || !c.fresh(info) {
+        return nil, false
+    }
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, false
+    }
+    return f, true
+}
+
+// write populates path atomically by writing to a temp file in the same
+// directory and renaming it into place, so a concurrent reader never sees a
+// partial entry.
+func (c *Cache) write(path string, create func(io.Writer) error) error {
+    tmp, err := os.CreateTemp(c.cfg.Dir, ".tmp-*")
+    if err != nil {
+        return fmt.Errorf("filecache: creating temp file: %v", err)
+    }
+    tmpName := tmp.Name()
+    defer os.Remove(tmpName) // no-op once renamed
+
+    if err := create(tmp); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return fmt.Errorf("filecache: closing temp file: %v", err)
+    }
+    if err := os.Rename(tmpName, path); err != nil {
+        return fmt.Errorf("filecache: installing cache entry: %v", err)
+    }
+    return nil
+}
+
+// evict removes expired entries and, if MaxSize is set, the least recently
+// used entries until the cache is back under budget. skip is the path of
+// the entry GetOrCreate just populated: it was only just written, so it is
+// never treated as expired here even on a Disabled cache, where fresh()
+// always reports false.
+func (c *Cache) evict(skip string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    entries, err := os.ReadDir(c.cfg.Dir)
+    if err != nil {
+        log.Printf("filecache[%s]: listing %s for eviction: %v", c.name, c.cfg.Dir, err)
+        return
+    }
+
+    type entry struct {
+        path string
+        info os.FileInfo
+    }
+    var live []entry
+    var total int64
+
+    for _, de := range entries {
+        if de.IsDir() || strings.HasSuffix(de.Name(), metaSuffix) {
+            continue
+        }
+        info, err := de.Info()
+        if err != nil {
+            continue
+        }
+        path := filepath.Join(c.cfg.Dir, de.Name())
+        if path != skip && !c.fresh(info) {
+            c.remove(path)
+            continue
+        }
+        live = append(live, entry{path: path, info: info})
+        total += info.Size()
+    }
+
+    if c.cfg.MaxSize <= 0 || total <= c.cfg.MaxSize {
+        return
+    }
+
+    // Oldest access time first so the least recently used entries go.
+    sort.Slice(live, func(i, j int) bool {
+        return accessTime(live[i].info).Before(accessTime(live[j].info))
+    })
+
+    for _, e := range live {
+        if total <= c.cfg.MaxSize {
+            break
+        }
+        c.remove(e.path)
+        total -= e.info.Size()
+    }
+}
+
+// remove deletes a cache entry and its sidecar metadata file, if any.
+func (c *Cache) remove(path string) {
+    if err := os.Remove(path); err != nil {
+        log.Printf("filecache[%s]: evicting entry %s: %v", c.name, path, err)
+        return
+    }
+    os.Remove(path + metaSuffix)
+}
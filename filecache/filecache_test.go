@@ -0,0 +1,178 @@
+package filecache
+
+import (
+    "io"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// TestGetOrCreateMissThenHit verifies that a miss invokes create and a
+// subsequent call within MaxAge reuses the cached entry without calling
+// create again.
+func TestGetOrCreateMissThenHit(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: time.Hour})
+    assert.NoError(t, err)
+
+    calls := 0
+    create := func(w io.Writer) error {
+        calls++
+        _, err := w.Write([]byte("hello"))
+        return err
+    }
+
+    r, err := c.GetOrCreate("key", create)
+    assert.NoError(t, err)
+    data, _ := io.ReadAll(r)
+    r.Close()
+    assert.Equal(t, "hello", string(data))
+    assert.Equal(t, 1, calls)
+
+    r, err = c.GetOrCreate("key", create)
+    assert.NoError(t, err)
+    data, _ = io.ReadAll(r)
+    r.Close()
+    assert.Equal(t, "hello", string(data))
+    assert.Equal(t, 1, calls, "second lookup should hit the cache, not call create again")
+}
+
+// TestGetOrCreateDisabled verifies MaxAge == Disabled always misses.
+func TestGetOrCreateDisabled(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: Disabled})
+    assert.NoError(t, err)
+
+    calls := 0
+    create := func(w io.Writer) error {
+        calls++
+        _, err := w.Write([]byte("data"))
+        return err
+    }
+
+    for i := 0; i < 2; i++ {
+        r, err := c.GetOrCreate("key", create)
+        assert.NoError(t, err)
+        r.Close()
+    }
+    assert.Equal(t, 2, calls)
+}
+
+// TestEvictMaxSize verifies the cache trims the least recently used entries
+// once total size exceeds MaxSize.
+func TestEvictMaxSize(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: Forever, MaxSize: 10})
+    assert.NoError(t, err)
+
+    write := func(key, data string) {
+        r, err := c.GetOrCreate(key, func(w io.Writer) error {
+            _, err := w.Write([]byte(data))
+            return err
+        })
+        assert.NoError(t, err)
+        r.Close()
+        time.Sleep(10 * time.Millisecond) // ensure distinct mtimes
+    }
+
+    write("a", "aaaaa")
+    write("b", "bbbbb")
+    write("c", "ccccc") // pushes total over MaxSize, "a" should be evicted
+
+    entries, err := os.ReadDir(dir)
+    assert.NoError(t, err)
+    assert.LessOrEqual(t, len(entries), 2)
+}
+
+// TestMeta verifies a missing sidecar reports ok == false and that stored
+// metadata round-trips.
+func TestMeta(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: Forever})
+    assert.NoError(t, err)
+
+    _, ok := c.Meta("key")
+    assert.False(t, ok)
+
+    assert.NoError(t, c.SetMeta("key", Metadata{ETag: `"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}))
+    m, ok := c.Meta("key")
+    assert.True(t, ok)
+    assert.Equal(t, `"abc"`, m.ETag)
+}
+
+// TestTouch verifies Touch bumps mtime without rewriting content.
+func TestTouch(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: time.Hour})
+    assert.NoError(t, err)
+
+    r, err := c.GetOrCreate("key", func(w io.Writer) error {
+        _, err := w.Write([]byte("data"))
+        return err
+    })
+    assert.NoError(t, err)
+    r.Close()
+
+    before, err := os.Stat(c.Path("key"))
+    assert.NoError(t, err)
+
+    time.Sleep(10 * time.Millisecond)
+    assert.NoError(t, c.Touch("key"))
+
+    after, err := os.Stat(c.Path("key"))
+    assert.NoError(t, err)
+    assert.True(t, after.ModTime().After(before.ModTime()))
+}
+
+// TestPeek verifies Peek reports a hit only once an entry exists, and never
+// invokes a create func.
+func TestPeek(t *testing.T) {
+    dir := t.TempDir()
+    c, err := New("test", Config{Dir: dir, MaxAge: time.Hour})
+    assert.NoError(t, err)
+
+    _, ok := c.Peek("key")
+    assert.False(t, ok)
+
+    r, err := c.GetOrCreate("key", func(w io.Writer) error {
+        _, err := w.Write([]byte("hello"))
+        return err
+    })
+    assert.NoError(t, err)
+    r.Close()
+
+    r, ok = c.Peek("key")
+    assert.True(t, ok)
+    data, _ := io.ReadAll(r)
+    r.Close()
+    assert.Equal(t, "hello", string(data))
+}
+
+// TestResolvePlaceholders verifies :cacheDir/:tempDir expansion.
+func TestResolvePlaceholders(t *testing.T) {
+    got := resolvePlaceholders(":cacheDir/pages", "cache")
+    assert.Equal(t, filepath.Join("cache", "pages"), filepath.Clean(got))
+
+    got = resolvePlaceholders(":tempDir/x", "cache")
+    assert.Equal(t, filepath.Join(os.TempDir(), "x"), filepath.Clean(got))
+}
+
+// TestManagerEnvOverride verifies NEWSPAPERS_CACHEDIR takes precedence over
+// the default cache directory passed to NewManager.
+func TestManagerEnvOverride(t *testing.T) {
+    root := t.TempDir()
+    os.Setenv(EnvCacheDir, root)
+    defer os.Unsetenv(EnvCacheDir)
+
+    m, err := NewManager(map[string]CacheConfig{
+        "pages": {Dir: ":cacheDir/pages", MaxAge: time.Hour},
+    }, "cache")
+    assert.NoError(t, err)
+
+    c, ok := m.Cache("pages")
+    assert.True(t, ok)
+    assert.Equal(t, filepath.Join(root, "pages"), c.cfg.Dir)
+}
@@ -0,0 +1,54 @@
+package filecache
+
+import (
+    "encoding/json"
+    "os"
+    "time"
+)
+
+// metaSuffix is appended to a cache entry's filename to store its sidecar
+// metadata file.
+const metaSuffix = ".meta.json"
+
+// Metadata is the small sidecar persisted alongside a cache entry so a
+// caller can make conditional upstream requests (If-None-Match /
+// If-Modified-Since) the next time the entry needs revalidating.
+type Metadata struct {
+    ETag         string `json:"etag,omitempty"`
+    LastModified string `json:"lastModified,omitempty"`
+}
+
+// metaPath returns the sidecar path for key's cache entry.
+func (c *Cache) metaPath(key string) string {
+    return c.filename(key) + metaSuffix
+}
+
+// Meta returns the stored Metadata for key, if any.
+func (c *Cache) Meta(key string) (Metadata, bool) {
+    data, err := os.ReadFile(c.metaPath(key))
+    if err != nil {
+        return Metadata{}, false
+    }
+    var m Metadata
+    if err := json.Unmarshal(data, &m); err != nil {
+        return Metadata{}, false
+    }
+    return m, true
+}
+
+// SetMeta persists Metadata for key, overwriting any previous value.
+func (c *Cache) SetMeta(key string, m Metadata) error {
+    data, err := json.Marshal(m)
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(c.metaPath(key), data, 0644)
+}
+
+// Touch bumps key's cache entry mtime to now without rewriting its
+// contents, extending its freshness window. Used when an upstream
+// conditional request comes back 304 Not Modified.
+func (c *Cache) Touch(key string) error {
+    now := time.Now()
+    return os.Chtimes(c.filename(key), now, now)
+}
@@ -0,0 +1,162 @@
+// Package imgproc applies deterministic post-processing to a decoded
+// image: resizing to a max width/height with a Lanczos filter, EXIF-aware
+// auto-orientation, optional grayscale, and encoding to JPEG/PNG/WebP/AVIF.
+// It sits between a cached source image (a downloaded cover or a
+// pdfrender.Page) and the HTTP response, so the same source can be
+// re-served at whatever size and format a given client wants.
+package imgproc
+
+import (
+    "bytes"
+    "fmt"
+    "image"
+    "image/jpeg"
+    "image/png"
+    "io"
+
+    "github.com/Kagami/go-avif"
+    "github.com/chai2010/webp"
+    "github.com/disintegration/imaging"
+)
+
+// Format is an output image encoding.
+type Format int
+
+const (
+    FormatJPEG Format = iota
+    FormatPNG
+    FormatWebP
+    FormatAVIF
+)
+
+// String returns the format's lowercase name, as used in query strings and
+// Content-Type headers.
+func (f Format) String() string {
+    switch f {
+    case FormatJPEG:
+        return "jpeg"
+    case FormatPNG:
+        return "png"
+    case FormatWebP:
+        return "webp"
+    case FormatAVIF:
+        return "avif"
+    default:
+        return "unknown"
+    }
+}
+
+// ContentType returns the MIME type to serve f as.
+func (f Format) ContentType() string {
+    return "image/" + f.String()
+}
+
+// ParseFormat parses the lowercase names accepted by String, plus the "jpg"
+// alias.
+func ParseFormat(s string) (Format, error) {
+    switch s {
+    case "jpeg", "jpg":
+        return FormatJPEG, nil
+    case "png":
+        return FormatPNG, nil
+    case "webp":
+        return FormatWebP, nil
+    case "avif":
+        return FormatAVIF, nil
+    default:
+        return 0, fmt.Errorf("imgproc: unknown format %q", s)
+    }
+}
+
+// Options controls how Process transforms a decoded image.
+type Options struct {
+    MaxWidth  int // 0 means unconstrained.
+    MaxHeight int // 0 means unconstrained.
+    Grayscale bool
+    Format    Format
+    Quality   int // JPEG/WebP/AVIF quality, 1-100. 0 uses a sensible default.
+}
+
+// Decode reads r as an image, auto-orienting it per any EXIF orientation
+// tag so callers never have to special-case sideways phone photos.
+func Decode(r io.Reader) (image.Image, error) {
+    return imaging.Decode(r, imaging.AutoOrientation(true))
+}
+
+// Resize scales img down so neither dimension exceeds maxWidth/maxHeight,
+// preserving aspect ratio, using a Lanczos resampling filter. A zero
+// maxWidth or maxHeight leaves that dimension unconstrained. Images already
+// within bounds are returned unchanged.
+func Resize(img image.Image, maxWidth, maxHeight int) image.Image {
+    b := img.Bounds()
+    w, h := b.Dx(), b.Dy()
+
+    fitW, fitH := maxWidth, maxHeight
+    if fitW == 0 {
+        fitW = w
+    }
+    if fitH == 0 {
+        fitH = h
+    }
+    if w <= fitW && h <= fitH {
+        return img
+    }
+    return imaging.Fit(img, fitW, fitH, imaging.Lanczos)
+}
+
+// Grayscale converts img to grayscale.
+func Grayscale(img image.Image) image.Image {
+    return imaging.Grayscale(img)
+}
+
+// Process resizes and, if requested, grayscales img per opts, then encodes
+// it to opts.Format. It's the single entry point callers need.
+func Process(img image.Image, opts Options) ([]byte, error) {
+    if opts.Quality == 0 {
+        opts.Quality = 85
+    }
+
+    img = Resize(img, opts.MaxWidth, opts.MaxHeight)
+    if opts.Grayscale {
+        img = Grayscale(img)
+    }
+    return Encode(img, opts.Format, opts.Quality)
+}
+
+// Encode renders img to the given format at the given quality (ignored for
+// PNG).
+func Encode(img image.Image, format Format, quality int) ([]byte, error) {
+    var buf bytes.Buffer
+    switch format {
+    case FormatPNG:
+        if err := png.Encode(&buf, img); err != nil {
+            return nil, err
+        }
+    case FormatWebP:
+        if err := webp.Encode(&buf, img, &webp.Options{Quality: float32(quality)}); err != nil {
+            return nil, err
+        }
+    case FormatAVIF:
+        if err := avif.Encode(&buf, img, &avif.Options{Quality: avifQuality(quality)}); err != nil {
+            return nil, err
+        }
+    default:
+        if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+            return nil, err
+        }
+    }
+    return buf.Bytes(), nil
+}
+
+// avifQuality maps our 1-100 "higher is better" quality scale onto
+// go-avif's 0-63 CQ scale, where *lower* is better (0 is lossless) and
+// anything above 63 is rejected outright.
+func avifQuality(quality int) int {
+    if quality < 1 {
+        quality = 1
+    }
+    if quality > 100 {
+        quality = 100
+    }
+    return (100 - quality) * 63 / 100
+}
@@ -0,0 +1,65 @@
+package imgproc
+
+import (
+    "image"
+    "image/color"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func sampleImage(w, h int) image.Image {
+    img := image.NewRGBA(image.Rect(0, 0, w, h))
+    for y := 0; y < h; y++ {
+        for x := 0; x < w; x++ {
+            img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0, A: 255})
+        }
+    }
+    return img
+}
+
+// TestResize verifies a too-large image is scaled down preserving aspect
+// ratio, and a within-bounds image is left unchanged.
+func TestResize(t *testing.T) {
+    img := Resize(sampleImage(200, 100), 50, 50)
+    b := img.Bounds()
+    assert.LessOrEqual(t, b.Dx(), 50)
+    assert.LessOrEqual(t, b.Dy(), 50)
+    assert.Equal(t, 2, b.Dx()/b.Dy())
+
+    unchanged := Resize(sampleImage(20, 10), 50, 50)
+    assert.Equal(t, 20, unchanged.Bounds().Dx())
+    assert.Equal(t, 10, unchanged.Bounds().Dy())
+}
+
+// TestGrayscale verifies the result has no color channel variation.
+func TestGrayscale(t *testing.T) {
+    img := Grayscale(sampleImage(10, 10))
+    r, g, b, _ := img.At(5, 5).RGBA()
+    assert.Equal(t, r, g)
+    assert.Equal(t, g, b)
+}
+
+// TestProcessFormats verifies every encoding path succeeds.
+func TestProcessFormats(t *testing.T) {
+    for _, format := range []Format{FormatJPEG, FormatPNG, FormatWebP, FormatAVIF} {
+        data, err := Process(sampleImage(40, 40), Options{MaxWidth: 20, MaxHeight: 20, Format: format})
+        assert.NoError(t, err, format.String())
+        assert.NotEmpty(t, data, format.String())
+    }
+}
+
+// TestParseFormat covers the accepted names, the jpg alias, and avif.
+func TestParseFormat(t *testing.T) {
+    f, err := ParseFormat("jpg")
+    assert.NoError(t, err)
+    assert.Equal(t, FormatJPEG, f)
+
+    f, err = ParseFormat("avif")
+    assert.NoError(t, err)
+    assert.Equal(t, FormatAVIF, f)
+    assert.Equal(t, "image/avif", f.ContentType())
+
+    _, err = ParseFormat("bogus")
+    assert.Error(t, err)
+}
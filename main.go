@@ -2,30 +2,94 @@
 package main
 
 import (
-    "bytes"
+    "context"
     "crypto/sha256"
+    "encoding/json"
     "fmt"
     "html/template"
     "io"
     "io/ioutil"
-    "image/jpeg"
     "log"
     "math/rand"
     "net/http"
     "os"
     "path/filepath"
+    "strconv"
     "strings"
     "time"
 
-    "github.com/PuerkitoBio/goquery"
-    "github.com/gen2brain/go-fitz"
     "gopkg.in/yaml.v2"
+
+    "github.com/harperreed/newspapers/filecache"
+    "github.com/harperreed/newspapers/imgproc"
+    "github.com/harperreed/newspapers/pdfrender"
+    "github.com/harperreed/newspapers/scheduler"
+    "github.com/harperreed/newspapers/sources"
 )
 
+// globalScheduler is the background prefetch scheduler started in main. It
+// is nil in tests that call the handlers directly, which all guard for that.
+var globalScheduler *scheduler.Scheduler
+
+// defaultCacheDir is the directory ":cacheDir" resolves to when neither
+// NEWSPAPERS_CACHEDIR nor an explicit path is configured.
+const defaultCacheDir = "cache"
+
+// maxThumbnails bounds how many thumbnails the home page strip renders for
+// a multi-page paper, so a 64-page edition doesn't rasterize every page.
+const maxThumbnails = 12
+
+// thumbMaxDimension is the longest edge, in pixels, of a thumbnail image.
+const thumbMaxDimension = 300
+
+// SourceConfig is a single configured newspaper. Provider is optional; when
+// empty it's autodetected from URL by sources.For.
+type SourceConfig struct {
+    URL      string `yaml:"url"`
+    Provider string `yaml:"provider"`
+    Refresh  string `yaml:"refresh"` // standard 5-field cron expression for background prefetch; empty means "prefetch once at startup only".
+}
+
+// jobName derives the scheduler.Job name for the source at position id in
+// resolveSources, matching what /paper/{id}/... routes call it.
+func jobName(id int) string {
+    return fmt.Sprintf("source-%d", id)
+}
+
 // Config struct defines the structure of the configuration file.
 type Config struct {
-    PDFURLs   []string      `yaml:"pdf_urls"`   // List of PDF URLs to process.
-    CacheTime time.Duration `yaml:"cache_time"` // Duration to cache the images before re-fetching.
+    PDFURLs   []string                        `yaml:"pdf_urls"`   // Deprecated: use Sources. List of URLs, autodetected by host.
+    Sources   []SourceConfig                  `yaml:"sources"`    // Configured newspapers, with optional explicit provider.
+    CacheTime time.Duration                   `yaml:"cache_time"` // Legacy cache duration, used for whichever of "pages", "pdfs", "thumbnails", "variants" isn't configured.
+    Caches    map[string]filecache.CacheConfig `yaml:"caches"`     // Named caches, e.g. "pages", "covers", "pdfs", "thumbnails".
+}
+
+// resolveSources returns config.Sources, falling back to wrapping the
+// deprecated PDFURLs list so existing config.yaml files keep working.
+func (c *Config) resolveSources() []SourceConfig {
+    if len(c.Sources) > 0 {
+        return c.Sources
+    }
+    srcs := make([]SourceConfig, len(c.PDFURLs))
+    for i, url := range c.PDFURLs {
+        srcs[i] = SourceConfig{URL: url}
+    }
+    return srcs
+}
+
+// sourceByID looks up a configured source by its position in
+// resolveSources, which is what the "id" path segment in /paper/{id}/...
+// routes refers to.
+func (c *Config) sourceByID(id string) (SourceConfig, error) {
+    idx, err := strconv.Atoi(id)
+    if err != nil {
+        return SourceConfig{}, fmt.Errorf("invalid paper id %q", id)
+    }
+    srcs := c.resolveSources()
+    if idx < 0 || idx >= len(srcs) {
+        return SourceConfig{}, fmt.Errorf("no paper with id %q", id)
+    }
+    return srcs[idx], nil
 }
 
 // loadConfig reads a YAML configuration file and unmarshals it into a Config struct.
@@ -48,232 +112,581 @@ func loadConfig(file string) (*Config, error) {
     return &config, nil
 }
 
-// generateCacheFilename generates a filename for caching the image. It creates a unique
-// filename by hashing the URL and appending the current date to ensure the cache file
-// is easily identifiable and includes a timestamp for potential cache invalidation purposes.
-// The SHA-256 hash function is used to generate a fixed-length hash from the URL.
-// The resulting filename is in the format of "<hash>_<date>.jpg".
-func generateCacheFilename(url string) string {
-    // Generate a hash of the URL using SHA-256 for a unique identifier
-    hash := sha256.Sum256([]byte(url))
-    hashStr := fmt.Sprintf("%x", hash)
-    log.Printf("Generated hash for URL '%s': %s", url, hashStr)
-
-    // Get today's date in the format MM-DD-YYYY for appending to the filename
-    today := time.Now().Format("01-02-2006")
-    log.Printf("Today's date for cache filename: %s", today)
-
-    // Create the cache filename using the hash and today's date
-    fileName := fmt.Sprintf("%s_%s.jpg", hashStr, today)
-    log.Printf("Generated cache filename: %s", fileName)
-
-    return fileName
+// newCacheManager builds the filecache.Manager for config.Caches. If no
+// "pages" cache is configured, one is synthesized from the legacy
+// CacheTime field so existing config.yaml files keep working unchanged.
+// "pdfs", "thumbnails", and "variants" caches are synthesized the same way
+// if absent, sharing the legacy cache time. "variants" holds the
+// imgproc-processed images served by imgHandler.
+func newCacheManager(config *Config) (*filecache.Manager, error) {
+    caches := make(map[string]filecache.CacheConfig, len(config.Caches)+4)
+    for name, cfg := range config.Caches {
+        caches[name] = cfg
+    }
+    for _, name := range []string{"pages", "pdfs", "thumbnails", "variants"} {
+        if _, ok := caches[name]; !ok {
+            caches[name] = filecache.CacheConfig{Dir: ":cacheDir/" + name, MaxAge: config.CacheTime}
+        }
+    }
+    return filecache.NewManager(caches, defaultCacheDir)
 }
 
-// getCoverURL fetches the cover image URL from a given webpage URL.
-func getCoverURL(url string) (string, error) {
-    log.Printf("Fetching cover URL from: %s", url)
-    res, err := http.Get(url)
+// conditionalFetch GETs url, sending If-None-Match/If-Modified-Since from
+// cache's stored Metadata for key if any is on record. If the upstream
+// responds 304 Not Modified, notModified is true and res is nil: the
+// caller's existing cache entry is still good and just needs its mtime
+// bumped. Otherwise the caller is responsible for closing res.Body and, on
+// success, persisting the response's new ETag/Last-Modified via SetMeta.
+func conditionalFetch(ctx context.Context, cache *filecache.Cache, key, url string) (res *http.Response, notModified bool, err error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
     if err != nil {
-        log.Printf("Error fetching URL: %v", err)
-        return "", err
+        return nil, false, err
+    }
+    if meta, ok := cache.Meta(key); ok {
+        if meta.ETag != "" {
+            req.Header.Set("If-None-Match", meta.ETag)
+        }
+        if meta.LastModified != "" {
+            req.Header.Set("If-Modified-Since", meta.LastModified)
+        }
     }
-    defer res.Body.Close()
 
-    doc, err := goquery.NewDocumentFromReader(res.Body)
+    res, err = http.DefaultClient.Do(req)
     if err != nil {
-        log.Printf("Error creating document from reader: %v", err)
-        return "", err
+        return nil, false, err
+    }
+    if res.StatusCode == http.StatusNotModified {
+        res.Body.Close()
+        return nil, true, nil
     }
+    if res.StatusCode != http.StatusOK {
+        res.Body.Close()
+        return nil, false, fmt.Errorf("server returned non-200 status code: %d", res.StatusCode)
+    }
+    return res, false, nil
+}
 
-    imgTag := doc.Find("img#giornale-img")
-    if imgTag.Length() > 0 {
-        src, exists := imgTag.Attr("src")
-        if exists {
-            coverURL := "https://www.frontpages.com" + src
-            log.Printf("Cover URL found: %s", coverURL)
-            return coverURL, nil
-        }
+// saveMeta records res's ETag/Last-Modified for key, if it sent either, so
+// the next revalidation can be conditional.
+func saveMeta(cache *filecache.Cache, key string, res *http.Response) {
+    meta := filecache.Metadata{ETag: res.Header.Get("ETag"), LastModified: res.Header.Get("Last-Modified")}
+    if meta.ETag == "" && meta.LastModified == "" {
+        return
+    }
+    if err := cache.SetMeta(key, meta); err != nil {
+        log.Printf("filecache: saving metadata for %q: %v", key, err)
     }
+}
 
-    errMsg := "Image not found or missing 'src' attribute"
-    log.Println(errMsg)
-    return "", fmt.Errorf(errMsg)
+// reuseCached copies the existing cache entry for key back into w, used
+// when an upstream conditional request comes back 304 Not Modified.
+func reuseCached(cache *filecache.Cache, key string, w io.Writer) error {
+    old, err := os.Open(cache.Path(key))
+    if err != nil {
+        return err
+    }
+    defer old.Close()
+    _, err = io.Copy(w, old)
+    return err
 }
 
-func downloadImage(url string) error {
-    log.Printf("Downloading image from URL: %s", url)
+// fetchPDF resolves src and returns the raw PDF bytes, cached under the
+// "pdfs" cache so that rendering several pages of the same edition only
+// downloads it once. It errors if src doesn't resolve to a PDF.
+func fetchPDF(ctx context.Context, manager *filecache.Manager, src SourceConfig) (io.ReadCloser, error) {
+    pdfs, ok := manager.Cache("pdfs")
+    if !ok {
+        return nil, fmt.Errorf("no \"pdfs\" cache configured")
+    }
+
+    return pdfs.GetOrCreate(src.URL, func(w io.Writer) error {
+        provider, err := sources.For(src.Provider, src.URL)
+        if err != nil {
+            return err
+        }
+        ref, err := provider.Resolve(ctx, src.URL)
+        if err != nil {
+            return err
+        }
+        if !ref.IsPDF {
+            return fmt.Errorf("%s does not resolve to a PDF", src.URL)
+        }
 
-    var imageURL string
-    if strings.HasPrefix(url, "https://www.frontpages.com") {
-        // Fetch the cover URL for frontpages.com URLs
-        var err error
-        imageURL, err = getCoverURL(url)
+        res, notModified, err := conditionalFetch(ctx, pdfs, src.URL, ref.URL)
         if err != nil {
-            log.Printf("Error getting cover URL: %v", err)
             return err
         }
-    } else {
-        // Use the provided URL directly for other URLs
-        imageURL = url
+        if notModified {
+            return reuseCached(pdfs, src.URL, w)
+        }
+        defer res.Body.Close()
+
+        if _, err := io.Copy(w, res.Body); err != nil {
+            return err
+        }
+        saveMeta(pdfs, src.URL, res)
+        return nil
+    })
+}
+
+// downloadImage resolves src through its sources.Provider, fetches the
+// resulting image or PDF, rasterizes PDFs to a JPEG cover, and writes the
+// result to w. It no longer touches the filesystem directly; the caller is
+// expected to be a filecache.Cache.GetOrCreate create func.
+func downloadImage(ctx context.Context, manager *filecache.Manager, src SourceConfig, w io.Writer) error {
+    log.Printf("Downloading image for: %s", src.URL)
+
+    provider, err := sources.For(src.Provider, src.URL)
+    if err != nil {
+        log.Printf("Error selecting provider: %v", err)
+        return err
+    }
+
+    ref, err := provider.Resolve(ctx, src.URL)
+    if err != nil {
+        log.Printf("Error resolving source: %v", err)
+        return err
+    }
+    log.Printf("Resolved image URL: %s (pdf=%v)", ref.URL, ref.IsPDF)
+
+    if ref.IsPDF {
+        rc, err := fetchPDF(ctx, manager, src)
+        if err != nil {
+            return err
+        }
+        defer rc.Close()
+
+        pages, err := pdfrender.Render(rc, pdfrender.RenderOptions{Pages: "1"})
+        if err != nil {
+            log.Printf("Error rendering PDF cover: %v", err)
+            return err
+        }
+        _, err = w.Write(pages[0].Data)
+        return err
     }
-    log.Printf("Image URL: %s", imageURL)
 
-    res, err := http.Get(imageURL)
+    pages, _ := manager.Cache("pages")
+    res, notModified, err := conditionalFetch(ctx, pages, src.URL, ref.URL)
     if err != nil {
         log.Printf("Error fetching image: %v", err)
         return err
     }
+    if notModified {
+        log.Printf("Image unchanged upstream, reusing cached copy: %s", ref.URL)
+        return reuseCached(pages, src.URL, w)
+    }
     defer res.Body.Close()
 
-    if res.StatusCode != http.StatusOK {
-        log.Printf("Error fetching image: server returned non-200 status code: %d", res.StatusCode)
-        return fmt.Errorf("server returned non-200 status code: %d", res.StatusCode)
+    log.Printf("Saving image from: %s", ref.URL)
+    if _, err := io.Copy(w, res.Body); err != nil {
+        return err
     }
+    saveMeta(pages, src.URL, res)
+    return nil
+}
 
-    cacheDir := "cache"
-    if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
-        log.Printf("Error creating cache directory: %v", err)
+// prefetchSource warms the "pages" cache entry for src, downloading (and,
+// for PDFs, rasterizing a cover for) it if the entry is missing or expired.
+// This is the one blocking operation both servePaperCover and the
+// background scheduler's Job.Fetch share.
+func prefetchSource(ctx context.Context, manager *filecache.Manager, src SourceConfig) error {
+    pages, ok := manager.Cache("pages")
+    if !ok {
+        return fmt.Errorf("no \"pages\" cache configured")
+    }
+    rc, err := pages.GetOrCreate(src.URL, func(w io.Writer) error {
+        return downloadImage(ctx, manager, src, w)
+    })
+    if err != nil {
         return err
     }
+    return rc.Close()
+}
 
-    // Generate the cache filename
-    fileName := generateCacheFilename(url)
+// renderedPageKey derives a filecache key for one rendered page so that
+// distinct pages, DPIs, and formats of the same edition don't collide.
+func renderedPageKey(url string, page int, dpi float64, format pdfrender.Format) string {
+    return fmt.Sprintf("%s|page=%d|dpi=%.0f|fmt=%s", url, page, dpi, format)
+}
 
-    cacheFile := filepath.Join(cacheDir, fileName)
+// renderPage renders a single page of src (caching raw PDF bytes and the
+// rendered output separately) and returns a reader over the cached image.
+func renderPage(ctx context.Context, manager *filecache.Manager, cacheName string, src SourceConfig, page int, dpi float64, maxDim int) (io.ReadCloser, error) {
+    cache, ok := manager.Cache(cacheName)
+    if !ok {
+        return nil, fmt.Errorf("no %q cache configured", cacheName)
+    }
 
-    // Convert PDF to image if the URL points to a PDF
-    if strings.HasSuffix(strings.ToLower(imageURL), ".pdf") {
-        log.Printf("Converting PDF to image: %s", imageURL)
-        imgData, err := convertPDFToImage(res.Body)
+    key := renderedPageKey(src.URL, page, dpi, pdfrender.FormatJPEG)
+    return cache.GetOrCreate(key, func(w io.Writer) error {
+        rc, err := fetchPDF(ctx, manager, src)
         if err != nil {
-            log.Printf("Error converting PDF to image: %v", err)
             return err
         }
-        if err := ioutil.WriteFile(cacheFile, imgData, 0644); err != nil {
-            log.Printf("Error writing image data to file: %v", err)
-            return err
-        }
-    } else {
-        // Save the image directly if it's not a PDF
-        file, err := os.Create(cacheFile)
+        defer rc.Close()
+
+        pages, err := pdfrender.Render(rc, pdfrender.RenderOptions{
+            Pages:        strconv.Itoa(page),
+            DPI:          dpi,
+            MaxDimension: maxDim,
+        })
         if err != nil {
-            log.Printf("Error creating cache file: %v", err)
             return err
         }
-        defer file.Close()
+        _, err = w.Write(pages[0].Data)
+        return err
+    })
+}
 
-        log.Printf("Saving image to cache file: %s", cacheFile)
-        imgData, err := ioutil.ReadAll(res.Body)
+// servePlaceholder responds with a minimal "still generating" page for a
+// prefetch cache miss, so homeHandler never blocks a request on a
+// download. The meta refresh gives the background scheduler a few seconds
+// to finish before the client tries again.
+func servePlaceholder(w http.ResponseWriter) {
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    fmt.Fprint(w, `<!DOCTYPE html><html><head><meta http-equiv="refresh" content="3"></head>`+
+        `<body><p>Generating today's front page&hellip;</p></body></html>`)
+}
+
+// homeHandler is the HTTP handler for the home page. It only ever reads
+// from cache: the "pages" and "pdfs" entries are kept warm by the
+// background scheduler started in main, so a request here never blocks on
+// a download or PDF render. manager is the shared filecache.Manager built
+// once in main, not rebuilt per request, so its singleflight coalescing
+// actually applies across concurrent requests.
+func homeHandler(manager *filecache.Manager) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        log.Println("Serving home page")
+        config, err := loadConfig("config.yaml")
         if err != nil {
-            log.Printf("Error reading image data: %v", err)
-            return err
+            log.Printf("Error loading configuration: %v", err)
+            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+            return
+        }
+
+        pages, _ := manager.Cache("pages")
+        pdfs, _ := manager.Cache("pdfs")
+
+        // Get a random source from the list
+        srcs := config.resolveSources()
+        rand.Seed(time.Now().UnixNano())
+        id := rand.Intn(len(srcs))
+        src := srcs[id]
+
+        rc, ok := pages.Peek(src.URL)
+        if !ok {
+            log.Printf("Cache miss for %s, nudging the background scheduler", src.URL)
+            if globalScheduler != nil {
+                go globalScheduler.RefreshNow(context.Background(), jobName(id))
+            }
+            servePlaceholder(w)
+            return
+        }
+        rc.Close()
+
+        var thumbs []string
+        if rc, ok := pdfs.Peek(src.URL); ok {
+            n, err := pdfrender.NumPages(rc)
+            rc.Close()
+            if err == nil {
+                if n > maxThumbnails {
+                    n = maxThumbnails
+                }
+                for p := 1; p <= n; p++ {
+                    thumbs = append(thumbs, fmt.Sprintf("/paper/%d/thumb/%d", id, p))
+                }
+            }
         }
 
-        _, err = file.Write(imgData)
+        tmpl := template.Must(template.ParseFiles("templates/home_with_image.html"))
+        data := struct {
+            ImageURL  string
+            ThumbURLs []string
+        }{
+            ImageURL:  "/" + pages.Path(src.URL),
+            ThumbURLs: thumbs,
+        }
+        err = tmpl.Execute(w, data)
         if err != nil {
-            log.Printf("Error writing image data to file: %v", err)
-            return err
+            log.Printf("Error executing template: %v", err)
+            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
         }
     }
-
-    log.Printf("Image downloaded and saved successfully")
-    return nil
 }
 
-func convertPDFToImage(pdfData io.Reader) ([]byte, error) {
-    tmpFile, err := ioutil.TempFile("", "temp-*.pdf")
-    if err != nil {
-        return nil, err
-    }
-    defer os.Remove(tmpFile.Name())
+// servePage handles both /paper/{id}/page/{n} and /paper/{id}/thumb/{n},
+// differing only in cache name, DPI, and max dimension. manager is the
+// shared filecache.Manager built once in main.
+func servePage(manager *filecache.Manager, cacheName string, dpi float64, maxDim int) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        config, err := loadConfig("config.yaml")
+        if err != nil {
+            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+            return
+        }
+        src, err := config.sourceByID(r.PathValue("id"))
+        if err != nil {
+            http.NotFound(w, r)
+            return
+        }
+        page, err := strconv.Atoi(r.PathValue("n"))
+        if err != nil || page < 1 {
+            http.Error(w, "invalid page number", http.StatusBadRequest)
+            return
+        }
 
-    _, err = io.Copy(tmpFile, pdfData)
-    if err != nil {
-        return nil, err
+        rc, err := renderPage(r.Context(), manager, cacheName, src, page, dpi, maxDim)
+        if err != nil {
+            log.Printf("Error rendering page: %v", err)
+            http.Error(w, "page not available", http.StatusInternalServerError)
+            return
+        }
+        defer rc.Close()
+
+        w.Header().Set("Content-Type", "image/jpeg")
+        io.Copy(w, rc)
     }
-    tmpFile.Close()
+}
 
-    doc, err := fitz.New(tmpFile.Name())
+// serveCacheEntry serves cache's entry for key via http.ServeContent,
+// giving the client free conditional GET support (If-None-Match /
+// If-Modified-Since) against a strong ETag derived from the entry's
+// content hash and its on-disk mtime.
+func serveCacheEntry(w http.ResponseWriter, r *http.Request, cache *filecache.Cache, key string) {
+    path := cache.Path(key)
+    f, err := os.Open(path)
     if err != nil {
-        return nil, err
+        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+        return
     }
-    defer doc.Close()
+    defer f.Close()
 
-    img, err := doc.Image(0)
+    info, err := f.Stat()
     if err != nil {
-        return nil, err
+        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+        return
     }
 
-    var buf bytes.Buffer
-    err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
-    if err != nil {
-        return nil, err
+    hash := sha256.New()
+    if _, err := io.Copy(hash, f); err != nil {
+        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+        return
+    }
+    if _, err := f.Seek(0, io.SeekStart); err != nil {
+        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+        return
     }
 
-    return buf.Bytes(), nil
+    w.Header().Set("ETag", fmt.Sprintf("%q", fmt.Sprintf("%x", hash.Sum(nil))))
+    http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
 }
 
-// homeHandler is the HTTP handler for the home page.
-func homeHandler(w http.ResponseWriter, r *http.Request) {
-    log.Println("Serving home page")
-    config, err := loadConfig("config.yaml")
-    if err != nil {
-        log.Printf("Error loading configuration: %v", err)
-        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-        return
+// negotiateFormat picks the imgproc.Format to serve: explicit, if given,
+// otherwise the most capable format accept claims support for (AVIF, then
+// WebP), falling back to JPEG.
+func negotiateFormat(accept, explicit string) (imgproc.Format, error) {
+    if explicit != "" {
+        return imgproc.ParseFormat(explicit)
+    }
+    switch {
+    case strings.Contains(accept, "image/avif"):
+        return imgproc.FormatAVIF, nil
+    case strings.Contains(accept, "image/webp"):
+        return imgproc.FormatWebP, nil
+    default:
+        return imgproc.FormatJPEG, nil
     }
+}
 
+// variantKey derives a filecache key for one processed variant of src so
+// that distinct sizes and formats of the same source image don't collide.
+func variantKey(src string, width, height int, format imgproc.Format) string {
+    return fmt.Sprintf("%s|w=%d|h=%d|fmt=%s", src, width, height, format)
+}
 
+// imgHandler serves a resized, format-negotiated variant of an already
+// cached source image, e.g. /img?src=<cacheKey>&w=800&fmt=webp. src is the
+// key under the "pages" cache (the same key downloadImage stores a cover
+// or page under); it returns 404 if that source isn't cached yet, rather
+// than triggering a download. manager is the shared filecache.Manager
+// built once in main.
+func imgHandler(manager *filecache.Manager) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        pages, _ := manager.Cache("pages")
+        variants, _ := manager.Cache("variants")
+
+        src := r.URL.Query().Get("src")
+        if src == "" {
+            http.Error(w, "missing src", http.StatusBadRequest)
+            return
+        }
+        width, _ := strconv.Atoi(r.URL.Query().Get("w"))
+        height, _ := strconv.Atoi(r.URL.Query().Get("h"))
+
+        format, err := negotiateFormat(r.Header.Get("Accept"), r.URL.Query().Get("fmt"))
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        key := variantKey(src, width, height, format)
+        rc, err := variants.GetOrCreate(key, func(out io.Writer) error {
+            f, ok := pages.Peek(src)
+            if !ok {
+                return fmt.Errorf("imgproc: no cached source for %q", src)
+            }
+            defer f.Close()
+
+            img, err := imgproc.Decode(f)
+            if err != nil {
+                return err
+            }
+            data, err := imgproc.Process(img, imgproc.Options{MaxWidth: width, MaxHeight: height, Format: format})
+            if err != nil {
+                return err
+            }
+            _, err = out.Write(data)
+            return err
+        })
+        if err != nil {
+            log.Printf("Error processing image variant: %v", err)
+            http.Error(w, "image not available", http.StatusNotFound)
+            return
+        }
+        defer rc.Close()
+
+        w.Header().Set("Vary", "Accept")
+        w.Header().Set("Content-Type", format.ContentType())
+        io.Copy(w, rc)
+    }
+}
 
-    // Get a random URL from the list
-    rand.Seed(time.Now().UnixNano())
-    currentURL := config.PDFURLs[rand.Intn(len(config.PDFURLs))]
-    // Generate the cache filename
-    fileName := generateCacheFilename(currentURL)
+// servePaperCover downloads (or reuses the cached copy of) src's cover and
+// serves it via serveCacheEntry.
+func servePaperCover(w http.ResponseWriter, r *http.Request, manager *filecache.Manager, src SourceConfig) {
+    if err := prefetchSource(r.Context(), manager, src); err != nil {
+        log.Printf("Error downloading image: %v", err)
+        http.Error(w, "No image available", http.StatusInternalServerError)
+        return
+    }
 
+    pages, _ := manager.Cache("pages")
+    serveCacheEntry(w, r, pages, src.URL)
+}
 
-    cacheFile := filepath.Join("cache", fileName)
+// currentPaperHandler serves the cover of a source picked at random on each
+// request (independently of homeHandler's pick), at a stable URL suitable
+// for linking or embedding directly (<img src="/paper/current">).
+// manager is the shared filecache.Manager built once in main.
+func currentPaperHandler(manager *filecache.Manager) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        config, err := loadConfig("config.yaml")
+        if err != nil {
+            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+            return
+        }
+
+        srcs := config.resolveSources()
+        rand.Seed(time.Now().UnixNano())
+        servePaperCover(w, r, manager, srcs[rand.Intn(len(srcs))])
+    }
+}
 
-    if _, err := os.Stat(cacheFile); os.IsNotExist(err) || time.Since(getFileModTime(cacheFile)) > config.CacheTime {
-        log.Printf("Image not in cache or cache expired, downloading new image")
-        err := downloadImage(currentURL)
+// paperHandler serves the cover of a specific configured source by id.
+// manager is the shared filecache.Manager built once in main.
+func paperHandler(manager *filecache.Manager) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        config, err := loadConfig("config.yaml")
         if err != nil {
-            log.Printf("Error downloading image: %v", err)
-            http.Error(w, "No image available", http.StatusInternalServerError)
+            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
             return
         }
-    } else {
-        log.Printf("Using cached image: %s", cacheFile)
+        src, err := config.sourceByID(r.PathValue("id"))
+        if err != nil {
+            http.NotFound(w, r)
+            return
+        }
+
+        servePaperCover(w, r, manager, src)
     }
+}
 
-    tmpl := template.Must(template.ParseFiles("templates/home_with_image.html"))
-    data := struct {
-        ImageURL string
-    }{
-        ImageURL: "/" + cacheFile,
+// refreshHandler triggers an immediate, out-of-band prefetch of every
+// configured source without waiting for it to finish, and responds with
+// every job's Status as JSON as it stood when the refresh was triggered.
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+    if globalScheduler == nil {
+        http.Error(w, "scheduler not running", http.StatusServiceUnavailable)
+        return
     }
-    err = tmpl.Execute(w, data)
-    if err != nil {
-        log.Printf("Error executing template: %v", err)
-        http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+    // The request's context is canceled once this handler returns, so the
+    // prefetch runs under its own.
+    globalScheduler.RefreshAll(context.Background())
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(globalScheduler.Statuses())
+}
+
+// healthzHandler reports every prefetch job's last success/error as JSON,
+// for uptime monitoring.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+    if globalScheduler == nil {
+        http.Error(w, "scheduler not running", http.StatusServiceUnavailable)
+        return
     }
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(globalScheduler.Statuses())
 }
 
-// getFileModTime returns the modification time of the specified file.
-func getFileModTime(file string) time.Time {
-    info, err := os.Stat(file)
-    if err != nil {
-        log.Printf("Error getting file modification time: %v", err)
-        return time.Time{}
+// startScheduler registers one background prefetch job per configured
+// source, keyed by jobName so homeHandler can nudge a specific one on a
+// cache miss, and starts it running in the background.
+func startScheduler(manager *filecache.Manager, srcs []SourceConfig) (*scheduler.Scheduler, error) {
+    sched := scheduler.New(2)
+    for i, src := range srcs {
+        src := src
+        err := sched.Add(scheduler.Job{
+            Name:    jobName(i),
+            Refresh: src.Refresh,
+            Fetch: func(ctx context.Context) error {
+                return prefetchSource(ctx, manager, src)
+            },
+        })
+        if err != nil {
+            return nil, err
+        }
     }
-    return info.ModTime()
+    go sched.Run(context.Background())
+    return sched, nil
 }
 
 // main sets up the HTTP server and its routes.
 func main() {
-    http.HandleFunc("/", homeHandler)
-    http.Handle("/cache/", http.StripPrefix("/cache/", http.FileServer(http.Dir("cache"))))
+    config, err := loadConfig("config.yaml")
+    if err != nil {
+        log.Fatalf("Error loading configuration: %v", err)
+    }
+    manager, err := newCacheManager(config)
+    if err != nil {
+        log.Fatalf("Error configuring cache: %v", err)
+    }
+    pages, _ := manager.Cache("pages")
+
+    sched, err := startScheduler(manager, config.resolveSources())
+    if err != nil {
+        log.Fatalf("Error starting scheduler: %v", err)
+    }
+    globalScheduler = sched
+
+    http.HandleFunc("/", homeHandler(manager))
+    http.HandleFunc("/paper/current", currentPaperHandler(manager))
+    http.HandleFunc("/paper/{id}", paperHandler(manager))
+    http.HandleFunc("/paper/{id}/page/{n}", servePage(manager, "pages", 0, 0))
+    http.HandleFunc("/paper/{id}/thumb/{n}", servePage(manager, "thumbnails", 0, thumbMaxDimension))
+    http.HandleFunc("/img", imgHandler(manager))
+    http.HandleFunc("/admin/refresh", refreshHandler)
+    http.HandleFunc("/healthz", healthzHandler)
+    http.Handle("/"+pages.Dir()+"/", http.StripPrefix("/"+pages.Dir()+"/", http.FileServer(http.Dir(pages.Dir()))))
 
     log.Println("Server started on http://localhost:8080")
     if err := http.ListenAndServe(":8080", nil); err != nil {
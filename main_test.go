@@ -2,6 +2,10 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +14,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"gopkg.in/yaml.v2"
+
+	"github.com/harperreed/newspapers/filecache"
+	"github.com/harperreed/newspapers/imgproc"
 )
 
 // TestLoadConfig tests the loadConfig function
@@ -57,72 +63,233 @@ cache_time: invalid
 	assert.Error(t, err)
 }
 
-// TestGenerateCacheFilename tests the generateCacheFilename function
-func TestGenerateCacheFilename(t *testing.T) {
-	url := "https://example.com/newspaper.pdf"
-	filename1 := generateCacheFilename(url)
-	filename2 := generateCacheFilename(url)
+// TestDownloadImage tests the downloadImage function
+func TestDownloadImage(t *testing.T) {
+	// Test successful image download
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	manager, err := filecache.NewManager(map[string]filecache.CacheConfig{
+		"pages": {Dir: ":cacheDir", MaxAge: time.Hour},
+		"pdfs":  {Dir: ":cacheDir/pdfs", MaxAge: time.Hour},
+	}, t.TempDir())
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = downloadImage(context.Background(), manager, SourceConfig{URL: server.URL, Provider: "image"}, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "fake image data", buf.String())
+
+	// Test error handling for network issues
+	buf.Reset()
+	err = downloadImage(context.Background(), manager, SourceConfig{URL: "http://non-existent-url.com", Provider: "image"}, &buf)
+	assert.Error(t, err)
+}
 
-	// Test correct filename generation
-	assert.Contains(t, filename1, ".jpg")
-	assert.Contains(t, filename2, ".jpg")
+// TestResolveSources tests the PDFURLs-to-Sources fallback.
+func TestResolveSources(t *testing.T) {
+	config := &Config{PDFURLs: []string{"https://example.com/a.pdf"}}
+	srcs := config.resolveSources()
+	assert.Equal(t, 1, len(srcs))
+	assert.Equal(t, "https://example.com/a.pdf", srcs[0].URL)
 
-	// Test consistency for the same URL
-	assert.Equal(t, filename1[:64], filename2[:64]) // Compare hash part
+	config = &Config{Sources: []SourceConfig{{URL: "https://example.com/b.pdf", Provider: "pdf"}}}
+	srcs = config.resolveSources()
+	assert.Equal(t, 1, len(srcs))
+	assert.Equal(t, "pdf", srcs[0].Provider)
 }
 
-// TestGetCoverURL tests the getCoverURL function
-func TestGetCoverURL(t *testing.T) {
-	// Test successful URL extraction
+// TestDownloadImageReusesCacheOn304 verifies a 304 response upstream
+// reuses the previously cached bytes instead of overwriting them.
+func TestDownloadImageReusesCacheOn304(t *testing.T) {
+	requests := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`<html><body><img id="giornale-img" src="/cover.jpg"></body></html>`))
+		w.Write([]byte("original bytes"))
 	}))
 	defer server.Close()
 
-	coverURL, err := getCoverURL(server.URL)
+	// MaxAge: Disabled forces every call to revalidate with the create func.
+	manager, err := filecache.NewManager(map[string]filecache.CacheConfig{
+		"pages": {Dir: ":cacheDir", MaxAge: filecache.Disabled},
+		"pdfs":  {Dir: ":cacheDir/pdfs", MaxAge: filecache.Disabled},
+	}, t.TempDir())
 	assert.NoError(t, err)
-	assert.Equal(t, "https://www.frontpages.com/cover.jpg", coverURL)
+	src := SourceConfig{URL: server.URL, Provider: "image"}
 
-	// Test error handling for invalid HTML
-	invalidServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`<html><body>No image here</body></html>`))
-	}))
-	defer invalidServer.Close()
+	pages, _ := manager.Cache("pages")
 
-	_, err = getCoverURL(invalidServer.URL)
-	assert.Error(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, downloadImage(context.Background(), manager, src, &buf))
+	assert.Equal(t, "original bytes", buf.String())
+	assert.Equal(t, 1, requests)
+
+	// Production only ever calls downloadImage as pages.GetOrCreate's create
+	// func, which leaves the final file on disk for the 304-reuse branch to
+	// read back. Mirror that here instead of calling downloadImage bare, or
+	// reuseCached has nothing to open.
+	rc, err := pages.GetOrCreate(src.URL, func(out io.Writer) error {
+		_, err := out.Write(buf.Bytes())
+		return err
+	})
+	assert.NoError(t, err)
+	rc.Close()
+
+	buf.Reset()
+	assert.NoError(t, downloadImage(context.Background(), manager, src, &buf))
+	assert.Equal(t, "original bytes", buf.String())
+	assert.Equal(t, 2, requests, "second call should have hit the server conditionally")
 }
 
-// TestDownloadImage tests the downloadImage function
-func TestDownloadImage(t *testing.T) {
-	// Test successful image download
+// TestPrefetchSource verifies prefetchSource warms the "pages" cache entry.
+func TestPrefetchSource(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("fake image data"))
+		w.Write([]byte("cover bytes"))
 	}))
 	defer server.Close()
 
-	err := downloadImage(server.URL)
+	manager, err := filecache.NewManager(map[string]filecache.CacheConfig{
+		"pages": {Dir: ":cacheDir", MaxAge: time.Hour},
+		"pdfs":  {Dir: ":cacheDir/pdfs", MaxAge: time.Hour},
+	}, t.TempDir())
 	assert.NoError(t, err)
+	src := SourceConfig{URL: server.URL, Provider: "image"}
 
-	// Test error handling for network issues
-	err = downloadImage("http://non-existent-url.com")
+	assert.NoError(t, prefetchSource(context.Background(), manager, src))
+
+	pages, _ := manager.Cache("pages")
+	rc, ok := pages.Peek(src.URL)
+	assert.True(t, ok)
+	data, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "cover bytes", string(data))
+}
+
+// TestHomeHandlerCacheMiss verifies homeHandler serves a placeholder
+// instead of blocking when its source isn't cached yet.
+func TestHomeHandlerCacheMiss(t *testing.T) {
+	tempDir := t.TempDir()
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(tempDir))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, ioutil.WriteFile("config.yaml", []byte(`
+pdf_urls:
+  - https://example.com/newspaper.pdf
+caches:
+  pages:
+    dir: pages
+    maxAge: 1h
+`), 0644))
+
+	config, err := loadConfig("config.yaml")
+	assert.NoError(t, err)
+	manager, err := newCacheManager(config)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	homeHandler(manager)(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Generating")
+}
+
+// TestNegotiateFormat verifies explicit fmt wins, then Accept sniffing,
+// then the JPEG fallback.
+func TestNegotiateFormat(t *testing.T) {
+	f, err := negotiateFormat("image/avif,image/webp", "png")
+	assert.NoError(t, err)
+	assert.Equal(t, imgproc.FormatPNG, f)
+
+	f, err = negotiateFormat("text/html,image/webp;q=0.9,*/*", "")
+	assert.NoError(t, err)
+	assert.Equal(t, imgproc.FormatWebP, f)
+
+	f, err = negotiateFormat("text/html", "")
+	assert.NoError(t, err)
+	assert.Equal(t, imgproc.FormatJPEG, f)
+
+	_, err = negotiateFormat("", "bogus")
 	assert.Error(t, err)
 }
 
-// TestConvertPDFToImage tests the convertPDFToImage function
-func TestConvertPDFToImage(t *testing.T) {
-	// Test successful PDF to image conversion
-	pdfData := []byte("%PDF-1.7\n1 0 obj\n<<\n/Type /Catalog\n/Pages 2 0 R\n>>\nendobj\n2 0 obj\n<<\n/Type /Pages\n/Kids [3 0 R]\n/Count 1\n>>\nendobj\n3 0 obj\n<<\n/Type /Page\n/Parent 2 0 R\n/Resources <<\n/Font <<\n/F1 4 0 R\n>>\n>>\n/MediaBox [0 0 300 144]\n/Contents 5 0 R\n>>\nendobj\n4 0 obj\n<<\n/Type /Font\n/Subtype /Type1\n/BaseFont /Helvetica\n>>\nendobj\n5 0 obj\n<< /Length 55 >>\nstream\nBT\n/F1 12 Tf\n100 100 Td\n(Hello, World!) Tj\nET\nendstream\nendobj\nxref\n0 6\n0000000000 65535 f \n0000000009 00000 n \n0000000058 00000 n \n0000000115 00000 n \n0000000274 00000 n \n0000000341 00000 n \ntrailer\n<<\n/Size 6\n/Root 1 0 R\n>>\nstartxref\n447\n%%EOF")
+// TestImgHandler verifies a cached source is served as a resized variant,
+// and an uncached src 404s instead of blocking on a download.
+func TestImgHandler(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(tempDir))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, ioutil.WriteFile("config.yaml", []byte(`
+pdf_urls:
+  - https://example.com/newspaper.pdf
+caches:
+  pages:
+    dir: pages
+    maxAge: 1h
+`), 0644))
+
+	config, err := loadConfig("config.yaml")
+	assert.NoError(t, err)
+	manager, err := newCacheManager(config)
+	assert.NoError(t, err)
+	pages, _ := manager.Cache("pages")
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	rc, err := pages.GetOrCreate("https://example.com/cover.png", func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+	assert.NoError(t, err)
+	rc.Close()
 
-	imgData, err := convertPDFToImage(bytes.NewReader(pdfData))
+	req := httptest.NewRequest(http.MethodGet, "/img?src=https://example.com/cover.png&w=10&fmt=png", nil)
+	rec := httptest.NewRecorder()
+	imgHandler(manager)(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Body.Bytes())
+
+	req = httptest.NewRequest(http.MethodGet, "/img?src=https://example.com/missing.png&fmt=png", nil)
+	rec = httptest.NewRecorder()
+	imgHandler(manager)(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestJobName verifies the scheduler job naming scheme matches paper ids.
+func TestJobName(t *testing.T) {
+	assert.Equal(t, "source-0", jobName(0))
+	assert.Equal(t, "source-3", jobName(3))
+}
+
+// TestSourceByID tests looking up a configured source by its id.
+func TestSourceByID(t *testing.T) {
+	config := &Config{PDFURLs: []string{"https://example.com/a.pdf", "https://example.com/b.pdf"}}
+
+	src, err := config.sourceByID("1")
 	assert.NoError(t, err)
-	assert.NotEmpty(t, imgData)
+	assert.Equal(t, "https://example.com/b.pdf", src.URL)
+
+	_, err = config.sourceByID("5")
+	assert.Error(t, err)
 
-	// Test error handling for invalid PDF data
-	invalidPDFData := []byte("This is not a valid PDF")
-	_, err = convertPDFToImage(bytes.NewReader(invalidPDFData))
+	_, err = config.sourceByID("not-a-number")
 	assert.Error(t, err)
 }
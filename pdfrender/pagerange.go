@@ -0,0 +1,53 @@
+package pdfrender
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// parsePageRange expands a page spec ("1", "1-3", "all") into 1-indexed
+// page numbers, bounded by numPages.
+func parsePageRange(spec string, numPages int) ([]int, error) {
+    if spec == "all" {
+        pages := make([]int, numPages)
+        for i := range pages {
+            pages[i] = i + 1
+        }
+        return pages, nil
+    }
+
+    if start, end, ok := strings.Cut(spec, "-"); ok {
+        lo, err := strconv.Atoi(start)
+        if err != nil {
+            return nil, fmt.Errorf("pdfrender: invalid page range %q", spec)
+        }
+        hi, err := strconv.Atoi(end)
+        if err != nil {
+            return nil, fmt.Errorf("pdfrender: invalid page range %q", spec)
+        }
+        if lo < 1 || hi < lo {
+            return nil, fmt.Errorf("pdfrender: invalid page range %q", spec)
+        }
+        if lo > numPages {
+            return nil, fmt.Errorf("pdfrender: page range %q starts past the document's %d pages", spec, numPages)
+        }
+        if hi > numPages {
+            hi = numPages
+        }
+        pages := make([]int, 0, hi-lo+1)
+        for n := lo; n <= hi; n++ {
+            pages = append(pages, n)
+        }
+        return pages, nil
+    }
+
+    n, err := strconv.Atoi(spec)
+    if err != nil {
+        return nil, fmt.Errorf("pdfrender: invalid page spec %q", spec)
+    }
+    if n < 1 || n > numPages {
+        return nil, fmt.Errorf("pdfrender: page %d out of range (document has %d pages)", n, numPages)
+    }
+    return []int{n}, nil
+}
@@ -0,0 +1,177 @@
+// Package pdfrender rasterizes PDF pages to images. Unlike a bare
+// doc.Image(0) call, it supports rendering any page or range of pages at a
+// configurable DPI, resizing to a max dimension, and encoding to any of
+// JPEG/PNG/WebP.
+package pdfrender
+
+import (
+    "bytes"
+    "fmt"
+    "image"
+    "image/jpeg"
+    "image/png"
+    "io"
+    "io/ioutil"
+    "os"
+
+    "github.com/chai2010/webp"
+    "github.com/gen2brain/go-fitz"
+)
+
+// Format is an output image encoding.
+type Format int
+
+const (
+    FormatJPEG Format = iota
+    FormatPNG
+    FormatWebP
+)
+
+// String returns the format's lowercase name, as used in config and URLs.
+func (f Format) String() string {
+    switch f {
+    case FormatJPEG:
+        return "jpeg"
+    case FormatPNG:
+        return "png"
+    case FormatWebP:
+        return "webp"
+    default:
+        return "unknown"
+    }
+}
+
+// ParseFormat parses the lowercase names accepted by String, plus the "jpg"
+// alias.
+func ParseFormat(s string) (Format, error) {
+    switch s {
+    case "jpeg", "jpg":
+        return FormatJPEG, nil
+    case "png":
+        return FormatPNG, nil
+    case "webp":
+        return FormatWebP, nil
+    default:
+        return 0, fmt.Errorf("pdfrender: unknown format %q", s)
+    }
+}
+
+// RenderOptions controls how Render rasterizes a PDF.
+type RenderOptions struct {
+    DPI          float64 // 0 uses go-fitz's default resolution (doc.Image).
+    Pages        string  // "1", "1-3", or "all". Empty defaults to "1".
+    Format       Format
+    Quality      int // JPEG/WebP quality, 1-100. 0 uses a sensible default.
+    MaxDimension int // 0 means no resizing.
+}
+
+// Page is one rendered page of a PDF.
+type Page struct {
+    Number int // 1-indexed, matching the page spec in RenderOptions.
+    Data   []byte
+    Format Format
+}
+
+// NumPages reports how many pages the PDF in r has, without rasterizing
+// any of them. Useful for building a page/thumbnail index before deciding
+// what to render.
+func NumPages(r io.Reader) (int, error) {
+    tmpFile, err := ioutil.TempFile("", "pdfrender-*.pdf")
+    if err != nil {
+        return 0, err
+    }
+    defer os.Remove(tmpFile.Name())
+
+    if _, err := io.Copy(tmpFile, r); err != nil {
+        tmpFile.Close()
+        return 0, err
+    }
+    tmpFile.Close()
+
+    doc, err := fitz.New(tmpFile.Name())
+    if err != nil {
+        return 0, err
+    }
+    defer doc.Close()
+
+    return doc.NumPage(), nil
+}
+
+// Render rasterizes the pages of r selected by opts.Pages, in order.
+func Render(r io.Reader, opts RenderOptions) ([]Page, error) {
+    if opts.Pages == "" {
+        opts.Pages = "1"
+    }
+    if opts.Quality == 0 {
+        opts.Quality = 90
+    }
+
+    tmpFile, err := ioutil.TempFile("", "pdfrender-*.pdf")
+    if err != nil {
+        return nil, err
+    }
+    defer os.Remove(tmpFile.Name())
+
+    if _, err := io.Copy(tmpFile, r); err != nil {
+        tmpFile.Close()
+        return nil, err
+    }
+    tmpFile.Close()
+
+    doc, err := fitz.New(tmpFile.Name())
+    if err != nil {
+        return nil, err
+    }
+    defer doc.Close()
+
+    numbers, err := parsePageRange(opts.Pages, doc.NumPage())
+    if err != nil {
+        return nil, err
+    }
+
+    pages := make([]Page, 0, len(numbers))
+    for _, n := range numbers {
+        var img image.Image
+        var err error
+        if opts.DPI > 0 {
+            img, err = doc.ImageDPI(n-1, opts.DPI)
+        } else {
+            img, err = doc.Image(n - 1)
+        }
+        if err != nil {
+            return nil, fmt.Errorf("pdfrender: rendering page %d: %v", n, err)
+        }
+
+        if opts.MaxDimension > 0 {
+            img = resizeMax(img, opts.MaxDimension)
+        }
+
+        data, err := encode(img, opts.Format, opts.Quality)
+        if err != nil {
+            return nil, fmt.Errorf("pdfrender: encoding page %d: %v", n, err)
+        }
+
+        pages = append(pages, Page{Number: n, Data: data, Format: opts.Format})
+    }
+
+    return pages, nil
+}
+
+func encode(img image.Image, format Format, quality int) ([]byte, error) {
+    var buf bytes.Buffer
+    switch format {
+    case FormatPNG:
+        if err := png.Encode(&buf, img); err != nil {
+            return nil, err
+        }
+    case FormatWebP:
+        if err := webp.Encode(&buf, img, &webp.Options{Quality: float32(quality)}); err != nil {
+            return nil, err
+        }
+    default:
+        if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+            return nil, err
+        }
+    }
+    return buf.Bytes(), nil
+}
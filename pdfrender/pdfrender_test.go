@@ -0,0 +1,89 @@
+package pdfrender
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+var samplePDF = []byte("%PDF-1.7\n1 0 obj\n<<\n/Type /Catalog\n/Pages 2 0 R\n>>\nendobj\n2 0 obj\n<<\n/Type /Pages\n/Kids [3 0 R]\n/Count 1\n>>\nendobj\n3 0 obj\n<<\n/Type /Page\n/Parent 2 0 R\n/Resources <<\n/Font <<\n/F1 4 0 R\n>>\n>>\n/MediaBox [0 0 300 144]\n/Contents 5 0 R\n>>\nendobj\n4 0 obj\n<<\n/Type /Font\n/Subtype /Type1\n/BaseFont /Helvetica\n>>\nendobj\n5 0 obj\n<< /Length 55 >>\nstream\nBT\n/F1 12 Tf\n100 100 Td\n(Hello, World!) Tj\nET\nendstream\nendobj\nxref\n0 6\n0000000000 65535 f \n0000000009 00000 n \n0000000058 00000 n \n0000000115 00000 n \n0000000274 00000 n \n0000000341 00000 n \ntrailer\n<<\n/Size 6\n/Root 1 0 R\n>>\nstartxref\n447\n%%EOF")
+
+// TestRenderSinglePage verifies the default "1" page spec renders exactly
+// page 1, and that invalid PDF data is an error.
+func TestRenderSinglePage(t *testing.T) {
+    pages, err := Render(bytes.NewReader(samplePDF), RenderOptions{})
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(pages))
+    assert.Equal(t, 1, pages[0].Number)
+    assert.NotEmpty(t, pages[0].Data)
+
+    // Test error handling for invalid PDF data
+    _, err = Render(bytes.NewReader([]byte("not a pdf")), RenderOptions{})
+    assert.Error(t, err)
+}
+
+// TestRenderFormats verifies PNG and WebP encoding paths both succeed.
+func TestRenderFormats(t *testing.T) {
+    for _, format := range []Format{FormatJPEG, FormatPNG, FormatWebP} {
+        pages, err := Render(bytes.NewReader(samplePDF), RenderOptions{Format: format})
+        assert.NoError(t, err, format.String())
+        assert.NotEmpty(t, pages[0].Data, format.String())
+    }
+}
+
+// TestRenderMaxDimension verifies rendering with a MaxDimension set succeeds
+// and produces image data.
+func TestRenderMaxDimension(t *testing.T) {
+    pages, err := Render(bytes.NewReader(samplePDF), RenderOptions{MaxDimension: 50})
+    assert.NoError(t, err)
+    assert.NotEmpty(t, pages[0].Data)
+}
+
+// TestParsePageRange covers "1", "1-3", and "all" specs plus invalid input.
+func TestParsePageRange(t *testing.T) {
+    pages, err := parsePageRange("1", 5)
+    assert.NoError(t, err)
+    assert.Equal(t, []int{1}, pages)
+
+    pages, err = parsePageRange("2-4", 5)
+    assert.NoError(t, err)
+    assert.Equal(t, []int{2, 3, 4}, pages)
+
+    pages, err = parsePageRange("all", 3)
+    assert.NoError(t, err)
+    assert.Equal(t, []int{1, 2, 3}, pages)
+
+    _, err = parsePageRange("0", 5)
+    assert.Error(t, err)
+
+    _, err = parsePageRange("10", 5)
+    assert.Error(t, err)
+
+    _, err = parsePageRange("10-20", 5)
+    assert.Error(t, err)
+}
+
+// TestNumPages verifies page counting without rendering.
+func TestNumPages(t *testing.T) {
+    n, err := NumPages(bytes.NewReader(samplePDF))
+    assert.NoError(t, err)
+    assert.Equal(t, 1, n)
+
+    _, err = NumPages(bytes.NewReader([]byte("not a pdf")))
+    assert.Error(t, err)
+}
+
+// TestParseFormat covers the accepted names and the jpg alias.
+func TestParseFormat(t *testing.T) {
+    f, err := ParseFormat("jpg")
+    assert.NoError(t, err)
+    assert.Equal(t, FormatJPEG, f)
+
+    f, err = ParseFormat("webp")
+    assert.NoError(t, err)
+    assert.Equal(t, FormatWebP, f)
+
+    _, err = ParseFormat("bogus")
+    assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package pdfrender
+
+import (
+    "image"
+)
+
+// resizeMax scales img down so neither dimension exceeds max, preserving
+// aspect ratio. Images already within bounds are returned unchanged. This
+// is a simple nearest-neighbor resize; imgproc.Resize offers a
+// higher-quality Lanczos resize for the final served image.
+func resizeMax(img image.Image, max int) image.Image {
+    b := img.Bounds()
+    w, h := b.Dx(), b.Dy()
+    if w <= max && h <= max {
+        return img
+    }
+
+    scale := float64(max) / float64(w)
+    if hs := float64(max) / float64(h); hs < scale {
+        scale = hs
+    }
+    newW := int(float64(w) * scale)
+    newH := int(float64(h) * scale)
+    if newW < 1 {
+        newW = 1
+    }
+    if newH < 1 {
+        newH = 1
+    }
+
+    dst := image.NewRGBA(image.Rect(0, 0, newW, newH))
+    for y := 0; y < newH; y++ {
+        srcY := b.Min.Y + y*h/newH
+        for x := 0; x < newW; x++ {
+            srcX := b.Min.X + x*w/newW
+            dst.Set(x, y, img.At(srcX, srcY))
+        }
+    }
+    return dst
+}
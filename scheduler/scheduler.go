@@ -0,0 +1,187 @@
+// Package scheduler runs per-source prefetch jobs in the background on a
+// cron-like schedule, so request handlers become pure cache lookups
+// instead of blocking on a download and PDF rasterization.
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/robfig/cron/v3"
+)
+
+// minBackoff and maxBackoff bound the exponential backoff applied after a
+// job's Fetch fails.
+const (
+    minBackoff = 30 * time.Second
+    maxBackoff = 30 * time.Minute
+)
+
+// Job is one source to keep prefetched.
+type Job struct {
+    Name    string                        // unique identifier, used in Status and RefreshNow.
+    Refresh string                        // standard 5-field cron expression, e.g. "0 6 * * *". Empty means "prefetch once at startup only" (a failed startup prefetch is retried with backoff until it succeeds).
+    Fetch   func(ctx context.Context) error
+}
+
+// Status reports a job's prefetch history, for /healthz and /admin/refresh.
+type Status struct {
+    Name        string
+    LastSuccess time.Time
+    LastError   string
+    NextRun     time.Time
+}
+
+type jobState struct {
+    job      Job
+    schedule cron.Schedule // nil if Refresh == ""
+
+    mu          sync.Mutex
+    lastSuccess time.Time
+    lastError   string
+    nextRun     time.Time
+    backoff     time.Duration
+}
+
+// Scheduler runs a set of Jobs with bounded concurrency.
+type Scheduler struct {
+    sem  chan struct{}
+    jobs []*jobState
+}
+
+// New creates a Scheduler that runs at most concurrency jobs at once.
+func New(concurrency int) *Scheduler {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    return &Scheduler{sem: make(chan struct{}, concurrency)}
+}
+
+// Add registers a job. Jobs can only be added before Run is called.
+func (s *Scheduler) Add(job Job) error {
+    var schedule cron.Schedule
+    if job.Refresh != "" {
+        var err error
+        schedule, err = cron.ParseStandard(job.Refresh)
+        if err != nil {
+            return fmt.Errorf("scheduler: parsing refresh %q for job %q: %v", job.Refresh, job.Name, err)
+        }
+    }
+    s.jobs = append(s.jobs, &jobState{job: job, schedule: schedule})
+    return nil
+}
+
+// Run prefetches every job once, retrying failures with backoff, then keeps
+// re-running each job that has a Refresh schedule until ctx is canceled. It
+// returns when ctx is done, or earlier if no job has a schedule and every
+// job's startup prefetch has succeeded.
+func (s *Scheduler) Run(ctx context.Context) {
+    var wg sync.WaitGroup
+    for _, js := range s.jobs {
+        wg.Add(1)
+        go func(js *jobState) {
+            defer wg.Done()
+            s.runLoop(ctx, js)
+        }(js)
+    }
+    wg.Wait()
+}
+
+func (s *Scheduler) runLoop(ctx context.Context, js *jobState) {
+    s.runOnce(ctx, js)
+    for {
+        js.mu.Lock()
+        // A job without a schedule is done once it has succeeded; until
+        // then, nextRun holds its backoff retry time.
+        done := js.schedule == nil && js.lastError == ""
+        delay := time.Until(js.nextRun)
+        js.mu.Unlock()
+        if done {
+            return
+        }
+        if delay < 0 {
+            delay = 0
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(delay):
+            s.runOnce(ctx, js)
+        }
+    }
+}
+
+// runOnce runs a job's Fetch, bounded by the worker semaphore, and updates
+// its Status bookkeeping.
+func (s *Scheduler) runOnce(ctx context.Context, js *jobState) {
+    s.sem <- struct{}{}
+    defer func() { <-s.sem }()
+
+    err := js.job.Fetch(ctx)
+
+    js.mu.Lock()
+    defer js.mu.Unlock()
+    if err != nil {
+        js.lastError = err.Error()
+        js.backoff = nextBackoff(js.backoff)
+        js.nextRun = time.Now().Add(js.backoff)
+        log.Printf("scheduler: %s: prefetch failed, retrying in %s: %v", js.job.Name, js.backoff, err)
+        return
+    }
+    js.lastError = ""
+    js.lastSuccess = time.Now()
+    js.backoff = 0
+    if js.schedule != nil {
+        js.nextRun = js.schedule.Next(time.Now())
+    }
+}
+
+// nextBackoff doubles prev, starting at minBackoff, capped at maxBackoff.
+func nextBackoff(prev time.Duration) time.Duration {
+    if prev < minBackoff {
+        return minBackoff
+    }
+    if next := prev * 2; next <= maxBackoff {
+        return next
+    }
+    return maxBackoff
+}
+
+// RefreshNow runs the named job immediately, outside its normal schedule.
+// It blocks until that job's Fetch returns.
+func (s *Scheduler) RefreshNow(ctx context.Context, name string) error {
+    for _, js := range s.jobs {
+        if js.job.Name == name {
+            s.runOnce(ctx, js)
+            return nil
+        }
+    }
+    return fmt.Errorf("scheduler: no such job %q", name)
+}
+
+// RefreshAll kicks off an immediate run of every job, without waiting for
+// them to finish.
+func (s *Scheduler) RefreshAll(ctx context.Context) {
+    for _, js := range s.jobs {
+        go s.runOnce(ctx, js)
+    }
+}
+
+// Statuses reports the current Status of every job, in registration order.
+func (s *Scheduler) Statuses() []Status {
+    out := make([]Status, len(s.jobs))
+    for i, js := range s.jobs {
+        js.mu.Lock()
+        out[i] = Status{
+            Name:        js.job.Name,
+            LastSuccess: js.lastSuccess,
+            LastError:   js.lastError,
+            NextRun:     js.nextRun,
+        }
+        js.mu.Unlock()
+    }
+    return out
+}
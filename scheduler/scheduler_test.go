@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// TestRunPrefetchesOnceAtStartup verifies a job with no Refresh runs
+// exactly once, immediately.
+func TestRunPrefetchesOnceAtStartup(t *testing.T) {
+    var calls int32
+    s := New(2)
+    assert.NoError(t, s.Add(Job{
+        Name: "one-shot",
+        Fetch: func(ctx context.Context) error {
+            atomic.AddInt32(&calls, 1)
+            return nil
+        },
+    }))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+    s.Run(ctx)
+
+    assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+    statuses := s.Statuses()
+    assert.Equal(t, 1, len(statuses))
+    assert.Empty(t, statuses[0].LastError)
+    assert.False(t, statuses[0].LastSuccess.IsZero())
+}
+
+// TestRunBacksOffOnFailure verifies a failing job's NextRun moves forward
+// and its LastError is recorded.
+func TestRunBacksOffOnFailure(t *testing.T) {
+    s := New(1)
+    assert.NoError(t, s.Add(Job{
+        Name:    "always-fails",
+        Refresh: "*/1 * * * *",
+        Fetch: func(ctx context.Context) error {
+            return fmt.Errorf("boom")
+        },
+    }))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+    defer cancel()
+    s.Run(ctx)
+
+    statuses := s.Statuses()
+    assert.Equal(t, "boom", statuses[0].LastError)
+    assert.True(t, statuses[0].NextRun.After(time.Now()))
+}
+
+// TestRefreshNow verifies RefreshNow runs a specific job out of band.
+func TestRefreshNow(t *testing.T) {
+    var calls int32
+    s := New(1)
+    assert.NoError(t, s.Add(Job{
+        Name: "job-a",
+        Fetch: func(ctx context.Context) error {
+            atomic.AddInt32(&calls, 1)
+            return nil
+        },
+    }))
+
+    assert.NoError(t, s.RefreshNow(context.Background(), "job-a"))
+    assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+    err := s.RefreshNow(context.Background(), "does-not-exist")
+    assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package sources
+
+import "context"
+
+func init() {
+    Register("pdf", nil, func() Provider { return &PDFProvider{} })
+    Register("image", nil, func() Provider { return &ImageProvider{} })
+}
+
+// PDFProvider is used for URLs that already point directly at a PDF.
+type PDFProvider struct{}
+
+// Resolve returns pageURL unchanged, marked as a PDF.
+func (p *PDFProvider) Resolve(ctx context.Context, pageURL string) (ImageRef, error) {
+    return ImageRef{URL: pageURL, IsPDF: true}, nil
+}
+
+// ImageProvider is used for URLs that already point directly at an image.
+type ImageProvider struct{}
+
+// Resolve returns pageURL unchanged.
+func (p *ImageProvider) Resolve(ctx context.Context, pageURL string) (ImageRef, error) {
+    return ImageRef{URL: pageURL}, nil
+}
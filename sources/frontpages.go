@@ -0,0 +1,49 @@
+package sources
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+
+    "github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+    Register("frontpages", []string{"frontpages.com"}, func() Provider { return &FrontpagesProvider{} })
+}
+
+// FrontpagesProvider scrapes the cover image out of a frontpages.com page,
+// e.g. https://www.frontpages.com/the-new-york-times/.
+type FrontpagesProvider struct{}
+
+// Resolve fetches pageURL and reads the "src" attribute off the
+// #giornale-img element.
+func (p *FrontpagesProvider) Resolve(ctx context.Context, pageURL string) (ImageRef, error) {
+    log.Printf("sources: frontpages: fetching %s", pageURL)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+    if err != nil {
+        return ImageRef{}, err
+    }
+    res, err := http.DefaultClient.Do(req)
+    if err != nil {
+        return ImageRef{}, err
+    }
+    defer res.Body.Close()
+
+    doc, err := goquery.NewDocumentFromReader(res.Body)
+    if err != nil {
+        return ImageRef{}, err
+    }
+
+    imgTag := doc.Find("img#giornale-img")
+    if imgTag.Length() > 0 {
+        if src, exists := imgTag.Attr("src"); exists {
+            coverURL := "https://www.frontpages.com" + src
+            log.Printf("sources: frontpages: cover URL found: %s", coverURL)
+            return ImageRef{URL: coverURL}, nil
+        }
+    }
+
+    return ImageRef{}, fmt.Errorf("sources: frontpages: image not found or missing 'src' attribute on %s", pageURL)
+}
@@ -0,0 +1,44 @@
+package sources
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+    Register("opengraph", nil, func() Provider { return &OpenGraphProvider{} })
+}
+
+// OpenGraphProvider is the generic fallback: it reads the og:image meta
+// tag from an arbitrary page, so newspapers that don't have a dedicated
+// provider still work as long as their page sets OpenGraph metadata.
+type OpenGraphProvider struct{}
+
+// Resolve fetches pageURL and returns the content of its og:image meta tag.
+func (p *OpenGraphProvider) Resolve(ctx context.Context, pageURL string) (ImageRef, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+    if err != nil {
+        return ImageRef{}, err
+    }
+    res, err := http.DefaultClient.Do(req)
+    if err != nil {
+        return ImageRef{}, err
+    }
+    defer res.Body.Close()
+
+    doc, err := goquery.NewDocumentFromReader(res.Body)
+    if err != nil {
+        return ImageRef{}, err
+    }
+
+    content, exists := doc.Find(`meta[property="og:image"]`).Attr("content")
+    if !exists || content == "" {
+        return ImageRef{}, fmt.Errorf("sources: opengraph: no og:image meta tag on %s", pageURL)
+    }
+
+    return ImageRef{URL: content, IsPDF: strings.HasSuffix(strings.ToLower(content), ".pdf")}, nil
+}
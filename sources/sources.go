@@ -0,0 +1,120 @@
+// Package sources resolves a configured newspaper URL down to a directly
+// fetchable image or PDF. Each newspaper provider (frontpages.com, Freedom
+// Forum's todaysfrontpages.com, a bare PDF/image URL, or a generic
+// OpenGraph scrape) implements Provider; Register lets third parties add
+// new adapters without touching core code.
+package sources
+
+import (
+    "context"
+    "fmt"
+    "net/url"
+    "strings"
+    "sync"
+)
+
+// ImageRef is what a Provider resolves a page URL to: a directly fetchable
+// URL plus whether it points at a PDF (and so needs rasterizing) or an
+// image that can be used as-is.
+type ImageRef struct {
+    URL   string
+    IsPDF bool
+}
+
+// Provider resolves a configured page URL to its underlying image or PDF.
+type Provider interface {
+    Resolve(ctx context.Context, pageURL string) (ImageRef, error)
+}
+
+// Factory constructs a new Provider instance.
+type Factory func() Provider
+
+type registration struct {
+    factory Factory
+    hosts   []string
+}
+
+var (
+    mu   sync.RWMutex
+    byName = map[string]registration{}
+)
+
+// Register adds a named provider to the registry. hosts is the set of
+// hostnames (and their subdomains) this provider should be autodetected
+// for; pass nil for providers that aren't tied to a host (e.g. "pdf",
+// "image", which For also picks by file extension) or that serve as a
+// catch-all (e.g. "opengraph").
+func Register(name string, hosts []string, factory Factory) {
+    mu.Lock()
+    defer mu.Unlock()
+    byName[name] = registration{factory: factory, hosts: hosts}
+}
+
+// Get looks up a provider by its registered name.
+func Get(name string) (Provider, bool) {
+    mu.RLock()
+    reg, ok := byName[name]
+    mu.RUnlock()
+    if !ok {
+        return nil, false
+    }
+    return reg.factory(), true
+}
+
+// Detect finds a provider registered for rawURL's host, if any.
+func Detect(rawURL string) (Provider, bool) {
+    u, err := url.Parse(rawURL)
+    if err != nil {
+        return nil, false
+    }
+    host := strings.ToLower(u.Hostname())
+
+    mu.RLock()
+    defer mu.RUnlock()
+    for _, reg := range byName {
+        for _, h := range reg.hosts {
+            if host == h || strings.HasSuffix(host, "."+h) {
+                return reg.factory(), true
+            }
+        }
+    }
+    return nil, false
+}
+
+// detectByExtension covers direct links that aren't tied to a particular
+// host: a bare ".pdf" or image URL.
+func detectByExtension(rawURL string) (Provider, bool) {
+    lower := strings.ToLower(rawURL)
+    switch {
+    case strings.HasSuffix(lower, ".pdf"):
+        return Get("pdf")
+    case strings.HasSuffix(lower, ".jpg"), strings.HasSuffix(lower, ".jpeg"),
+        strings.HasSuffix(lower, ".png"), strings.HasSuffix(lower, ".webp"):
+        return Get("image")
+    default:
+        return nil, false
+    }
+}
+
+// For selects the provider to use for pageURL: explicitName if given,
+// otherwise autodetection by host, then by file extension, and finally the
+// generic OpenGraph scraper as a last resort.
+func For(explicitName, pageURL string) (Provider, error) {
+    if explicitName != "" {
+        p, ok := Get(explicitName)
+        if !ok {
+            return nil, fmt.Errorf("sources: unknown provider %q", explicitName)
+        }
+        return p, nil
+    }
+    if p, ok := Detect(pageURL); ok {
+        return p, nil
+    }
+    if p, ok := detectByExtension(pageURL); ok {
+        return p, nil
+    }
+    if p, ok := Get("opengraph"); ok {
+        return p, nil
+    }
+    return nil, fmt.Errorf("sources: no provider available for %q", pageURL)
+}
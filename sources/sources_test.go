@@ -0,0 +1,75 @@
+package sources
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// TestDetectByHost verifies autodetection picks providers by registered host.
+func TestDetectByHost(t *testing.T) {
+    p, ok := Detect("https://www.frontpages.com/the-times/")
+    assert.True(t, ok)
+    assert.IsType(t, &FrontpagesProvider{}, p)
+
+    p, ok = Detect("https://www.todaysfrontpages.com/newspaper/NYT")
+    assert.True(t, ok)
+    assert.IsType(t, &TodaysFrontPagesProvider{}, p)
+
+    _, ok = Detect("https://example.com/whatever")
+    assert.False(t, ok)
+}
+
+// TestForFallsBackToExtensionThenOpenGraph verifies the selection order in For.
+func TestForFallsBackToExtensionThenOpenGraph(t *testing.T) {
+    p, err := For("", "https://example.com/paper.pdf")
+    assert.NoError(t, err)
+    assert.IsType(t, &PDFProvider{}, p)
+
+    p, err = For("", "https://example.com/paper.jpg")
+    assert.NoError(t, err)
+    assert.IsType(t, &ImageProvider{}, p)
+
+    p, err = For("", "https://example.com/some-page")
+    assert.NoError(t, err)
+    assert.IsType(t, &OpenGraphProvider{}, p)
+
+    _, err = For("bogus", "https://example.com/some-page")
+    assert.Error(t, err)
+}
+
+// TestTodaysFrontPagesProviderResolve verifies slug-based PDF resolution.
+func TestTodaysFrontPagesProviderResolve(t *testing.T) {
+    p := &TodaysFrontPagesProvider{}
+    ref, err := p.Resolve(context.Background(), "https://www.todaysfrontpages.com/newspaper/NYT")
+    assert.NoError(t, err)
+    assert.Equal(t, "https://www.todaysfrontpages.com/pdf/NYT.pdf", ref.URL)
+    assert.True(t, ref.IsPDF)
+}
+
+// TestOpenGraphProviderResolve verifies og:image scraping.
+func TestOpenGraphProviderResolve(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte(`<html><head><meta property="og:image" content="https://example.com/cover.jpg"></head></html>`))
+    }))
+    defer server.Close()
+
+    p := &OpenGraphProvider{}
+    ref, err := p.Resolve(context.Background(), server.URL)
+    assert.NoError(t, err)
+    assert.Equal(t, "https://example.com/cover.jpg", ref.URL)
+    assert.False(t, ref.IsPDF)
+
+    missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte(`<html><head></head></html>`))
+    }))
+    defer missing.Close()
+
+    _, err = p.Resolve(context.Background(), missing.URL)
+    assert.Error(t, err)
+}